@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// statusResponse is the JSON shape returned by /api/status. It mirrors
+// SupervisorStatus but adds the derived uptime, since clients shouldn't
+// have to compute it from started_at themselves.
+type statusResponse struct {
+	State         SupervisorState `json:"state"`
+	Profile       string          `json:"profile,omitempty"`
+	PID           int             `json:"pid,omitempty"`
+	UptimeSeconds float64         `json:"uptime_seconds"`
+	LastExitCode  int             `json:"last_exit_code"`
+	RetryCount    int             `json:"retry_count"`
+	LastError     string          `json:"last_error,omitempty"`
+}
+
+func handleAPIStatus(w http.ResponseWriter, r *http.Request) {
+	st := sup.Snapshot()
+	resp := statusResponse{
+		State:         st.State,
+		Profile:       st.Profile,
+		PID:           st.PID,
+		UptimeSeconds: st.UptimeSeconds(),
+		LastExitCode:  st.LastExitCode,
+		RetryCount:    st.RetryCount,
+		LastError:     st.LastError,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleAPIConfig implements GET/PUT for the full config document.
+// AuthPassword is never sent back to the client.
+func handleAPIConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		cfg, err := loadConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		cfg.AuthPassword = ""
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(cfg); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+	case http.MethodPut:
+		var cfg Config
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			http.Error(w, "Bad request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, p := range cfg.Profiles {
+			if !validProfileName(p.Name) {
+				http.Error(w, "invalid profile name: "+p.Name, http.StatusBadRequest)
+				return
+			}
+		}
+		// AuthPassword is redacted to "" on GET, so a GET-edit-PUT round
+		// trip — the only workflow this endpoint supports — would otherwise
+		// write an empty password back to disk and leave AuthUsername
+		// reachable with a blank password. Carry forward the on-disk value
+		// whenever the client didn't supply a new one.
+		if cfg.AuthPassword == "" {
+			if existing, err := loadConfig(); err == nil {
+				cfg.AuthPassword = existing.AuthPassword
+			}
+		}
+		if err := saveConfig(&cfg); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPICrashes lists recently recovered goroutine panics, most recent
+// last, so an operator can tell the process kept running through a bug
+// instead of dying silently.
+func handleAPICrashes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(Crashes()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleAPIControl wraps a zero-arg control action (start/stop/restart) as
+// a POST-only JSON endpoint, guarded by an X-CSRF-Token header so a
+// third-party page can't trigger it via an auto-submitting form riding on
+// the browser's cached Basic Auth credentials.
+func handleAPIControl(action func(), message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !checkCSRFHeader(r) {
+			http.Error(w, "Missing or invalid X-CSRF-Token header", http.StatusForbidden)
+			return
+		}
+		action()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+	}
+}
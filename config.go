@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// CameraProfile describes a single camera's VLC launch parameters. Multiple
+// profiles can be kept in the config at once; Config.Selected picks which
+// one the supervisor is currently running.
+type CameraProfile struct {
+	Name      string   `json:"name"`
+	RTSPURL   string   `json:"rtsp_url"`
+	Transport string   `json:"transport,omitempty"` // "tcp" or "udp", passed to VLC's rtsp-tcp flag
+	VLCFlags  []string `json:"vlc_flags,omitempty"`
+
+	// Restream makes the supervisor ask VLC to also write an HLS playlist
+	// under hlsBaseDir, served by the web server at /hls/<name>/, so the
+	// camera can be viewed in a browser instead of only on a local display.
+	Restream             bool `json:"restream,omitempty"`
+	Transcode            bool `json:"transcode,omitempty"` // false = pass-through the source codec into HLS
+	TranscodeBitrateKbps int  `json:"transcode_bitrate_kbps,omitempty"`
+	TranscodeWidth       int  `json:"transcode_width,omitempty"`
+	TranscodeHeight      int  `json:"transcode_height,omitempty"`
+}
+
+type Config struct {
+	VLCPath      string          `json:"vlc_path,omitempty"`
+	Selected     string          `json:"selected"`
+	Profiles     []CameraProfile `json:"profiles"`
+	StartSeconds int             `json:"start_seconds,omitempty"` // min uptime (s) to count as a successful start; default 1
+	StartRetries int             `json:"start_retries,omitempty"` // consecutive fast failures allowed before Fatal; default 3
+
+	AuthUsername string `json:"auth_username,omitempty"` // HTTP Basic Auth; auth is disabled if empty
+	AuthPassword string `json:"auth_password,omitempty"`
+	TLSCertFile  string `json:"tls_cert_file,omitempty"` // both must be set to serve over TLS
+	TLSKeyFile   string `json:"tls_key_file,omitempty"`
+
+	LogLevel  string `json:"log_level,omitempty"`  // debug|info|warn|error, default info
+	LogFormat string `json:"log_format,omitempty"` // json|logfmt, default logfmt
+}
+
+// ActiveProfile returns the profile named by Selected, if any.
+func (c *Config) ActiveProfile() (*CameraProfile, bool) {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == c.Selected {
+			return &c.Profiles[i], true
+		}
+	}
+	return nil, false
+}
+
+func (c *Config) findProfile(name string) int {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// profileNameRe restricts CameraProfile.Name to safe path-segment
+// characters. Profile names are used verbatim as a directory name under
+// hlsBaseDir (see restream.go) and reach us straight from the HTTP form or
+// JSON API, so anything letting "../" through would turn restream-enabled
+// profiles into an arbitrary-path RemoveAll/MkdirAll primitive.
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validProfileName reports whether name is safe to use as an HLS directory
+// name. Callers accepting a profile name from the web form or the JSON API
+// must check this before saving the config.
+func validProfileName(name string) bool {
+	return profileNameRe.MatchString(name)
+}
+
+// ---- config helpers ----
+
+func loadConfig() (*Config, error) {
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+
+	f, err := os.Open(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg := &Config{}
+	if err := json.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", configPath, err)
+	}
+
+	// Defaults
+	if cfg.VLCPath == "" {
+		cfg.VLCPath = "cvlc"
+	}
+
+	return cfg, nil
+}
+
+func saveConfig(newCfg *Config) error {
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+
+	f, err := os.Create(configPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newCfg)
+}
+
+// watchConfig watches the directory containing configPath for external
+// edits (e.g. someone SSHing in and editing the file by hand) and triggers
+// the same restart path the web UI uses. We watch the directory rather than
+// the file itself so the watch survives editors that replace the file via
+// rename instead of writing in place.
+//
+// It only triggers a restart when the *active* profile actually changed.
+// saveConfig is shared by every config-mutating handler, including ones
+// that add/delete an unrelated profile, so without this check any edit at
+// all would cut the currently-streaming camera out for no reason.
+func watchConfig(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		appLog.Error("config watcher: failed to init fsnotify", "err", err)
+		return
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(configPath)
+	if err := watcher.Add(dir); err != nil {
+		appLog.Error("config watcher: failed to watch directory", "dir", dir, "err", err)
+		return
+	}
+
+	appLog.Info("watching config file for external changes", "path", configPath)
+
+	lastActive := currentActiveProfile()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(configPath) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			active := currentActiveProfile()
+			if activeProfileEqual(lastActive, active) {
+				continue
+			}
+			lastActive = active
+
+			appLog.Info("config file changed externally, triggering restart")
+			select {
+			case restartVLCCh <- struct{}{}:
+			default:
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			appLog.Error("config watcher error", "err", err)
+		}
+	}
+}
+
+// currentActiveProfile reads configPath and returns a copy of its active
+// profile, or nil if the config can't be read or no profile is selected.
+func currentActiveProfile() *CameraProfile {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	p, ok := cfg.ActiveProfile()
+	if !ok {
+		return nil
+	}
+	active := *p
+	return &active
+}
+
+// activeProfileEqual reports whether two active-profile snapshots are the
+// same, so watchConfig and the web handlers that save config can tell a
+// restart-worthy change (the running profile's settings changed) apart
+// from an unrelated one (e.g. a different profile was added or deleted).
+func activeProfileEqual(a, b *CameraProfile) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(*a, *b)
+}
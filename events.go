@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// SupervisorEvent is a single state-transition notification pushed to
+// /ws/events subscribers.
+type SupervisorEvent struct {
+	Time    time.Time       `json:"time"`
+	Type    SupervisorState `json:"type"`
+	Profile string          `json:"profile,omitempty"`
+	Detail  string          `json:"detail,omitempty"`
+}
+
+// EventBroadcaster fans supervisor events out to any number of subscribers,
+// following the same per-subscriber-channel pattern as LogBuffer.
+type EventBroadcaster struct {
+	mu   sync.Mutex
+	subs map[chan SupervisorEvent]struct{}
+}
+
+var supEvents = &EventBroadcaster{subs: make(map[chan SupervisorEvent]struct{})}
+
+func (b *EventBroadcaster) Publish(ev SupervisorEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			// Slow subscriber; drop rather than block the supervisor loop.
+		}
+	}
+}
+
+func (b *EventBroadcaster) Subscribe() (ch chan SupervisorEvent, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch = make(chan SupervisorEvent, 32)
+	b.subs[ch] = struct{}{}
+
+	cancel = func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+	return ch, cancel
+}
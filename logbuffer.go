@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// LogBuffer is an io.Writer that keeps the last N lines written to it in
+// memory and fans new lines out to any subscribers. It's used as VLC's
+// stdout/stderr so the web UI can tail the process live instead of only
+// seeing it in this process's own stdout.
+type LogBuffer struct {
+	mu      sync.Mutex
+	lines   []string
+	max     int
+	partial string
+	subs    map[chan string]struct{}
+}
+
+func NewLogBuffer(max int) *LogBuffer {
+	return &LogBuffer{max: max, subs: make(map[chan string]struct{})}
+}
+
+// vlcLog buffers VLC's combined stdout/stderr for /ws/logs subscribers.
+var vlcLog = NewLogBuffer(1000)
+
+// Write implements io.Writer. It also mirrors everything to os.Stdout so
+// journalctl/systemd logging keeps working exactly as before.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	os.Stdout.Write(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.partial += string(p)
+	for {
+		idx := strings.IndexByte(b.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		line := strings.TrimRight(b.partial[:idx], "\r")
+		b.partial = b.partial[idx+1:]
+		b.pushLocked(line)
+	}
+	return len(p), nil
+}
+
+func (b *LogBuffer) pushLocked(line string) {
+	b.lines = append(b.lines, line)
+	if len(b.lines) > b.max {
+		b.lines = b.lines[len(b.lines)-b.max:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- line:
+		default:
+			// Subscriber isn't keeping up; drop the line for them rather
+			// than block VLC's output pipe.
+		}
+	}
+}
+
+// Subscribe returns a channel of new lines plus a snapshot of the buffered
+// history, and a cancel func to unsubscribe. Each subscriber gets its own
+// channel so one slow WebSocket client can't stall the others or VLC.
+func (b *LogBuffer) Subscribe() (ch chan string, history []string, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch = make(chan string, 256)
+	b.subs[ch] = struct{}{}
+	history = append([]string(nil), b.lines...)
+
+	cancel = func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+	return ch, history, cancel
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// appLog is replaced by configureLogging once the config has been read; it
+// starts with a sane default so early startup logging (before the first
+// loadConfig call) still goes somewhere sensible.
+var appLog = slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+// configureLogging swaps appLog's handler based on cfg.LogLevel/LogFormat.
+// Called once at startup; the log format/level isn't hot-reloaded along
+// with the rest of the config.
+func configureLogging(cfg *Config) {
+	opts := &slog.HandlerOptions{Level: parseLogLevel(cfg.LogLevel)}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.LogFormat) {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	default:
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	appLog = slog.New(handler)
+}
+
+func parseLogLevel(s string) slog.Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const crashLogDir = "/var/log/camplayer-vlc"
+
+// CrashReport records a single recovered panic for /api/logs/crashes.
+type CrashReport struct {
+	Time      time.Time `json:"time"`
+	Goroutine string    `json:"goroutine"`
+	Panic     string    `json:"panic"`
+	Path      string    `json:"path,omitempty"`
+}
+
+const maxRecordedCrashes = 100
+
+var (
+	crashMu sync.Mutex
+	crashes []CrashReport
+)
+
+func recordCrash(cr CrashReport) {
+	crashMu.Lock()
+	defer crashMu.Unlock()
+	crashes = append(crashes, cr)
+	if len(crashes) > maxRecordedCrashes {
+		crashes = crashes[len(crashes)-maxRecordedCrashes:]
+	}
+}
+
+// Crashes returns a copy of the recently recovered panics, most recent last.
+func Crashes() []CrashReport {
+	crashMu.Lock()
+	defer crashMu.Unlock()
+	return append([]CrashReport(nil), crashes...)
+}
+
+// goSafe runs fn in a new goroutine, recovering any panic so a bug in one
+// part of the supervisor (a handler, the wait goroutine, etc.) can't take
+// down the whole process and the VLC child with it.
+func goSafe(name string, fn func()) {
+	go func() {
+		defer recoverPanic(name)
+		fn()
+	}()
+}
+
+func recoverPanic(name string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	appLog.Error("recovered from panic", "goroutine", name, "panic", fmt.Sprint(r))
+
+	path, err := writeCrashFile(name, r, stack)
+	if err != nil {
+		appLog.Error("failed to write crash report", "err", err)
+	}
+
+	recordCrash(CrashReport{
+		Time:      time.Now(),
+		Goroutine: name,
+		Panic:     fmt.Sprint(r),
+		Path:      path,
+	})
+}
+
+func writeCrashFile(name string, r any, stack []byte) (string, error) {
+	if err := os.MkdirAll(crashLogDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", crashLogDir, err)
+	}
+
+	now := time.Now()
+	path := filepath.Join(crashLogDir, fmt.Sprintf("crash-%s-%s.log", name, now.UTC().Format("20060102T150405Z")))
+
+	content := fmt.Sprintf("time: %s\ngoroutine: %s\npanic: %v\n\n%s\n", now.Format(time.RFC3339), name, r, stack)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("writing crash file: %w", err)
+	}
+	return path, nil
+}
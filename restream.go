@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// hlsBaseDir holds one subdirectory per restreaming profile, each
+// containing a live-updating HLS playlist and its segments. The web server
+// serves this tree directly under /hls/.
+const hlsBaseDir = "/var/run/camplayer-vlc/hls"
+
+func hlsProfileDir(profileName string) string {
+	return filepath.Join(hlsBaseDir, profileName)
+}
+
+// prepareHLSDir wipes and recreates a profile's segment directory so a
+// fresh VLC run never mixes its segments with a stale playlist left behind
+// by a previous run or a different profile.
+//
+// profileName ends up in a filepath.Join below, and the config this came
+// from may have reached us via a hand-edited config file picked up by
+// watchConfig rather than the validated HTTP handlers, so re-check it here
+// rather than trusting callers to have validated it already.
+func prepareHLSDir(profileName string) error {
+	if !validProfileName(profileName) {
+		return fmt.Errorf("refusing to prepare HLS dir: invalid profile name %q", profileName)
+	}
+	dir := hlsProfileDir(profileName)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clearing stale HLS segments: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating HLS segment dir: %w", err)
+	}
+	return nil
+}
+
+// buildRestreamSout builds the VLC --sout chain that duplicates the stream
+// into an HLS playlist, transcoding first if the profile asks for it.
+func buildRestreamSout(p *CameraProfile) string {
+	dir := hlsProfileDir(p.Name)
+	playlist := filepath.Join(dir, "stream.m3u8")
+	segment := filepath.Join(dir, "segment-########.ts")
+
+	livehttp := fmt.Sprintf(
+		"std{access=livehttp{seglen=4,delsegs=true,numsegs=5,index=%s,index-url=segment-########.ts},mux=ts,dst=%s}",
+		playlist, segment,
+	)
+
+	if !p.Transcode {
+		return "#" + livehttp
+	}
+
+	bitrate := p.TranscodeBitrateKbps
+	if bitrate <= 0 {
+		bitrate = 1024
+	}
+	transcode := fmt.Sprintf("vcodec=h264,vb=%d,acodec=mpga,ab=128", bitrate)
+	if p.TranscodeWidth > 0 {
+		transcode += fmt.Sprintf(",width=%d", p.TranscodeWidth)
+	}
+	if p.TranscodeHeight > 0 {
+		transcode += fmt.Sprintf(",height=%d", p.TranscodeHeight)
+	}
+
+	return fmt.Sprintf("#transcode{%s}:%s", transcode, livehttp)
+}
+
+// hlsPlaylistURL is what the web UI points its <video> element at for a
+// restreaming profile.
+func hlsPlaylistURL(profileName string) string {
+	return "/hls/" + profileName + "/stream.m3u8"
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ---- supervisor state ----
+
+// SupervisorState mirrors the supervisord-style process state machine:
+// Starting -> Running -> (Backoff -> Starting)* -> Fatal | Stopped.
+type SupervisorState string
+
+const (
+	StateStopped  SupervisorState = "stopped"
+	StateStarting SupervisorState = "starting"
+	StateRunning  SupervisorState = "running"
+	StateBackoff  SupervisorState = "backoff"
+	StateFatal    SupervisorState = "fatal"
+)
+
+const (
+	defaultStartSeconds = 1 * time.Second
+	defaultStartRetries = 3
+)
+
+// SupervisorStatus is the current, observable state of the VLC process.
+// It's read by the web UI and the /api/status endpoint.
+type SupervisorStatus struct {
+	State        SupervisorState `json:"state"`
+	Profile      string          `json:"profile,omitempty"`
+	PID          int             `json:"pid,omitempty"`
+	StartedAt    time.Time       `json:"started_at,omitempty"`
+	LastExitCode int             `json:"last_exit_code"`
+	RetryCount   int             `json:"retry_count"`
+	LastError    string          `json:"last_error,omitempty"`
+}
+
+// UptimeSeconds is the number of seconds the current process has been
+// running, or 0 if it isn't currently running.
+func (s SupervisorStatus) UptimeSeconds() float64 {
+	if s.State != StateRunning || s.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.StartedAt).Seconds()
+}
+
+// Supervisor guards SupervisorStatus with a mutex, the same pattern
+// loadConfig/saveConfig use for cfgMu.
+type Supervisor struct {
+	mu     sync.Mutex
+	status SupervisorStatus
+}
+
+var sup = &Supervisor{status: SupervisorStatus{State: StateStopped}}
+
+func (s *Supervisor) Snapshot() SupervisorStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *Supervisor) update(fn func(*SupervisorStatus)) {
+	s.mu.Lock()
+	fn(&s.status)
+	st := s.status
+	s.mu.Unlock()
+
+	supEvents.Publish(SupervisorEvent{
+		Time:    time.Now(),
+		Type:    st.State,
+		Profile: st.Profile,
+		Detail:  st.LastError,
+	})
+}
+
+// ---- manual control ----
+
+// controlMu guards manualStop, which tracks whether the user asked the
+// supervisor to stop (via the UI or POST /api/stop) so runLoop knows to
+// wait rather than relaunch VLC.
+var (
+	controlMu  sync.Mutex
+	manualStop bool
+)
+
+func isManualStop() bool {
+	controlMu.Lock()
+	defer controlMu.Unlock()
+	return manualStop
+}
+
+// requestStop tells the supervisor to stop and keep the current process
+// down until requestStart is called.
+func requestStop() {
+	controlMu.Lock()
+	manualStop = true
+	controlMu.Unlock()
+	select {
+	case restartVLCCh <- struct{}{}:
+	default:
+	}
+}
+
+// requestStart clears a manual stop (or a Fatal state) and asks the
+// supervisor to (re)launch the active profile.
+func requestStart() {
+	controlMu.Lock()
+	manualStop = false
+	controlMu.Unlock()
+	select {
+	case restartVLCCh <- struct{}{}:
+	default:
+	}
+}
+
+// requestRestart kills and relaunches the current process without touching
+// manualStop.
+func requestRestart() {
+	select {
+	case restartVLCCh <- struct{}{}:
+	default:
+	}
+}
+
+// ---- VLC supervisor loop ----
+
+func runLoop(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			appLog.Info("shutdown requested, exiting supervisor loop")
+			return nil
+		default:
+		}
+
+		if isManualStop() {
+			sup.update(func(st *SupervisorStatus) {
+				st.State = StateStopped
+				st.PID = 0
+				st.LastError = ""
+			})
+			if sleepOrRestart(ctx, 2*time.Second) == backoffShutdown {
+				return nil
+			}
+			continue
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			appLog.Warn("failed to load config, retrying", "err", err)
+			sup.update(func(st *SupervisorStatus) {
+				st.State = StateStopped
+				st.LastError = err.Error()
+			})
+			if sleepOrRestart(ctx, 2*time.Second) == backoffShutdown {
+				return nil
+			}
+			continue
+		}
+
+		profile, ok := cfg.ActiveProfile()
+		if !ok || profile.RTSPURL == "" {
+			appLog.Warn("no active camera profile with an RTSP URL, waiting", "config_path", configPath)
+			sup.update(func(st *SupervisorStatus) {
+				st.State = StateStopped
+				st.Profile = ""
+				st.LastError = "no active camera profile configured"
+			})
+			if sleepOrRestart(ctx, 2*time.Second) == backoffShutdown {
+				return nil
+			}
+			continue
+		}
+
+		if shutdown := runProfileLoop(ctx, cfg, profile); shutdown {
+			return nil
+		}
+		// Otherwise a restart/config-change was requested: reload config and go again.
+	}
+}
+
+// runProfileLoop runs one camera profile through Starting/Running/Backoff,
+// escalating to Fatal after StartRetries consecutive fast failures. It
+// returns true if the caller should shut down, false if it should reload
+// config and pick a (possibly different) profile.
+func runProfileLoop(ctx context.Context, cfg *Config, profile *CameraProfile) (shutdown bool) {
+	startSeconds := time.Duration(cfg.StartSeconds) * time.Second
+	if startSeconds <= 0 {
+		startSeconds = defaultStartSeconds
+	}
+	startRetries := cfg.StartRetries
+	if startRetries <= 0 {
+		startRetries = defaultStartRetries
+	}
+
+	backoff := 2 * time.Second
+	const maxBackoff = 30 * time.Second
+	fastFailures := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		default:
+		}
+
+		sup.update(func(st *SupervisorStatus) {
+			st.State = StateStarting
+			st.Profile = profile.Name
+			st.PID = 0
+			st.RetryCount = fastFailures
+		})
+
+		if profile.Restream {
+			if err := prepareHLSDir(profile.Name); err != nil {
+				appLog.Error("restream: failed to prepare HLS directory", "profile", profile.Name, "err", err)
+			}
+		}
+
+		args := buildVLCArgs(profile)
+		cmd := exec.CommandContext(ctx, cfg.VLCPath, args...)
+		cmd.Stdout = vlcLog
+		cmd.Stderr = vlcLog
+
+		appLog.Info("launching VLC", "profile", profile.Name, "vlc_path", cfg.VLCPath, "args", args)
+
+		if err := cmd.Start(); err != nil {
+			appLog.Error("failed to start VLC", "err", err)
+			fastFailures++
+			if fastFailures >= startRetries {
+				if goFatal(ctx, profile.Name, fastFailures, err) {
+					return true
+				}
+				// Restart requested from Fatal: reload config in case it
+				// was edited, rather than retrying with this stale profile.
+				return false
+			}
+			switch sleepOrRestart(ctx, backoff) {
+			case backoffShutdown:
+				return true
+			case backoffRestarted:
+				return false
+			}
+			backoff = nextBackoff(backoff, maxBackoff)
+			continue
+		}
+
+		startedAt := time.Now()
+		sup.update(func(st *SupervisorStatus) {
+			st.State = StateRunning
+			st.PID = cmd.Process.Pid
+			st.StartedAt = startedAt
+			st.LastError = ""
+		})
+
+		waitCh := make(chan error, 1)
+		goSafe("cmd.Wait", func() { waitCh <- cmd.Wait() })
+
+		select {
+		case <-ctx.Done():
+			appLog.Info("shutdown requested, killing VLC")
+			_ = cmd.Process.Kill()
+			<-waitCh
+			return true
+
+		case <-restartVLCCh:
+			appLog.Info("restart requested, killing VLC")
+			_ = cmd.Process.Kill()
+			<-waitCh
+			return false
+
+		case err := <-waitCh:
+			uptime := time.Since(startedAt)
+			appLog.Info("VLC exited", "uptime", uptime.String(), "err", err)
+
+			sup.update(func(st *SupervisorStatus) {
+				st.State = StateBackoff
+				st.PID = 0
+				st.LastExitCode = exitCode(err)
+				if err != nil {
+					st.LastError = err.Error()
+				}
+			})
+
+			if uptime < startSeconds {
+				fastFailures++
+			} else {
+				fastFailures = 0
+				backoff = 2 * time.Second
+			}
+
+			if fastFailures >= startRetries {
+				if goFatal(ctx, profile.Name, fastFailures, err) {
+					return true
+				}
+				// Restart requested from Fatal: reload config in case it
+				// was edited, rather than retrying with this stale profile.
+				return false
+			}
+
+			switch sleepOrRestart(ctx, backoff) {
+			case backoffShutdown:
+				return true
+			case backoffRestarted:
+				return false
+			}
+			backoff = nextBackoff(backoff, maxBackoff)
+		}
+	}
+}
+
+// goFatal marks the supervisor Fatal and blocks until the user asks for a
+// restart (clicking "Start" in the UI, or editing/reselecting the config,
+// both of which signal restartVLCCh) or the process is shutting down.
+// It returns true if the caller should shut down.
+func goFatal(ctx context.Context, profileName string, retries int, lastErr error) bool {
+	appLog.Error("profile failed to start repeatedly, entering Fatal state", "profile", profileName, "retries", retries)
+	sup.update(func(st *SupervisorStatus) {
+		st.State = StateFatal
+		st.RetryCount = retries
+		if lastErr != nil {
+			st.LastError = lastErr.Error()
+		}
+	})
+
+	select {
+	case <-ctx.Done():
+		return true
+	case <-restartVLCCh:
+		appLog.Info("restart requested from Fatal state")
+		return false
+	}
+}
+
+// buildVLCArgs turns a camera profile into the cvlc argument list: the RTSP
+// URL, an optional transport hint, and any extra flags the user configured.
+func buildVLCArgs(p *CameraProfile) []string {
+	args := []string{}
+	switch p.Transport {
+	case "tcp":
+		args = append(args, "--rtsp-tcp")
+	case "udp", "":
+		// UDP is VLC's default; nothing to add.
+	}
+	args = append(args, p.VLCFlags...)
+	if p.Restream {
+		args = append(args, "--sout", buildRestreamSout(p), "--sout-keep")
+	}
+	args = append(args, p.RTSPURL)
+	return args
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// backoffOutcome distinguishes why sleepOrRestart returned, since a caller
+// in runProfileLoop needs to treat an explicit restart signal differently
+// from the backoff timer simply elapsing: a restart signal (button click or
+// a config file edit observed by watchConfig) means the config may have
+// changed and should be reloaded, so runProfileLoop must bubble back out to
+// runLoop rather than relaunching the same, possibly-stale profile.
+type backoffOutcome int
+
+const (
+	backoffElapsed backoffOutcome = iota
+	backoffRestarted
+	backoffShutdown
+)
+
+func sleepOrRestart(ctx context.Context, d time.Duration) backoffOutcome {
+	select {
+	case <-ctx.Done():
+		appLog.Info("shutdown during backoff")
+		return backoffShutdown
+	case <-restartVLCCh:
+		appLog.Info("restart requested during backoff")
+		return backoffRestarted
+	case <-time.After(d):
+		return backoffElapsed
+	}
+}
+
+func nextBackoff(current, max time.Duration) time.Duration {
+	current *= 2
+	if current > max {
+		return max
+	}
+	return current
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hlsJS is the hls.js "light" build (no deprecated codec/subtitle support we
+// don't need), embedded so the live view works in Chrome/Firefox too: native
+// <video> HLS playback is Safari-only, and this device may not have a route
+// to a public CDN. See THIRD_PARTY_NOTICES.hls.js.LICENSE for its license.
+//
+//go:embed hls.min.js
+var hlsJS []byte
+
+var pageTmpl = template.Must(template.New("page").Parse(`
+<!doctype html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>camplayer-vlc configuration</title>
+	<style>
+		body { font-family: sans-serif; max-width: 700px; margin: 2rem auto; }
+		label { display: block; margin-bottom: 0.5rem; font-weight: bold; }
+		input[type=text] { width: 100%; padding: 0.5rem; }
+		button { margin-top: 1rem; padding: 0.5rem 1rem; }
+		.msg { color: green; margin-bottom: 1rem; }
+		.error { color: red; margin-bottom: 1rem; }
+		table { width: 100%; border-collapse: collapse; margin-bottom: 1.5rem; }
+		th, td { text-align: left; padding: 0.4rem; border-bottom: 1px solid #ddd; }
+		.selected { font-weight: bold; }
+		#log { background: #111; color: #ddd; font-family: monospace; font-size: 0.85rem;
+			height: 260px; overflow-y: scroll; padding: 0.5rem; white-space: pre-wrap; }
+		#state-indicator { display: inline-block; width: 0.8em; height: 0.8em; border-radius: 50%;
+			background: #999; margin-right: 0.4em; }
+		#state-indicator.running { background: #2a2; }
+		#state-indicator.backoff, #state-indicator.starting { background: #d90; }
+		#state-indicator.fatal { background: #c33; }
+	</style>
+</head>
+<body>
+	<h1>camplayer-vlc configuration</h1>
+
+	{{if .Message}}
+	<div class="msg">{{.Message}}</div>
+	{{end}}
+	{{if .Error}}
+	<div class="error">{{.Error}}</div>
+	{{end}}
+
+	<h2>Status</h2>
+	<p>
+		<span id="state-indicator" class="{{.Status.State}}"></span>
+		State: <strong id="state-label">{{.Status.State}}</strong>
+		{{if .Status.Profile}} &middot; Profile: <span id="state-profile">{{.Status.Profile}}</span>{{end}}
+		{{if .Status.PID}} &middot; PID: {{.Status.PID}}{{end}}
+		&middot; Retry count: {{.Status.RetryCount}}
+		&middot; Last exit code: {{.Status.LastExitCode}}
+		{{if .Status.LastError}} &middot; Last error: <span id="state-error">{{.Status.LastError}}</span>{{end}}
+	</p>
+	{{if eq .Status.State "fatal"}}
+	<form method="POST" action="/supervisor/start">
+		<input type="hidden" name="csrf_token" value="{{$.CSRFToken}}">
+		<button type="submit">Start</button>
+	</form>
+	{{end}}
+
+	{{if .HLSPlaylistURL}}
+	<h2>Live view</h2>
+	<video id="hls-video" data-src="{{.HLSPlaylistURL}}" controls autoplay muted style="width:100%;background:#000;">
+		<source src="{{.HLSPlaylistURL}}" type="application/vnd.apple.mpegurl">
+	</video>
+	<script src="/assets/hls.min.js"></script>
+	<script>
+		(function() {
+			var video = document.getElementById("hls-video");
+			var src = video.dataset.src;
+			if (video.canPlayType("application/vnd.apple.mpegurl")) {
+				return; // Safari: native HLS, the <source> tag above is enough
+			}
+			if (window.Hls && Hls.isSupported()) {
+				var hls = new Hls();
+				hls.loadSource(src);
+				hls.attachMedia(video);
+			}
+		})();
+	</script>
+	{{end}}
+
+	<h2>Live log</h2>
+	<pre id="log"></pre>
+
+	<h2>Camera profiles</h2>
+	<table>
+		<tr><th>Name</th><th>RTSP URL</th><th></th></tr>
+		{{range .Profiles}}
+		<tr{{if eq .Name $.Selected}} class="selected"{{end}}>
+			<td>{{.Name}}{{if eq .Name $.Selected}} (active){{end}}</td>
+			<td>{{.RTSPURL}}</td>
+			<td>
+				<form style="display:inline" method="POST" action="/profile/select">
+					<input type="hidden" name="name" value="{{.Name}}">
+					<input type="hidden" name="csrf_token" value="{{$.CSRFToken}}">
+					<button type="submit">Select</button>
+				</form>
+				<form style="display:inline" method="POST" action="/profile/delete">
+					<input type="hidden" name="name" value="{{.Name}}">
+					<input type="hidden" name="csrf_token" value="{{$.CSRFToken}}">
+					<button type="submit">Delete</button>
+				</form>
+			</td>
+		</tr>
+		{{else}}
+		<tr><td colspan="3">No camera profiles configured yet.</td></tr>
+		{{end}}
+	</table>
+
+	<h2>Add a profile</h2>
+	<form method="POST" action="/profile/add">
+		<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+		<label for="name">Name</label>
+		<input type="text" id="name" name="name">
+
+		<label for="rtsp_url">RTSP URL</label>
+		<input type="text" id="rtsp_url" name="rtsp_url">
+
+		<label for="transport">Transport (tcp/udp, default udp)</label>
+		<input type="text" id="transport" name="transport">
+
+		<label for="restream">
+			<input type="checkbox" id="restream" name="restream" value="1" style="width:auto;display:inline;">
+			Restream to browser (HLS)
+		</label>
+
+		<label for="transcode">
+			<input type="checkbox" id="transcode" name="transcode" value="1" style="width:auto;display:inline;">
+			Transcode (unchecked = pass-through the source codec)
+		</label>
+
+		<label for="bitrate">Transcode bitrate (kbps, default 1024)</label>
+		<input type="text" id="bitrate" name="bitrate">
+
+		<label for="width">Transcode width (optional)</label>
+		<input type="text" id="width" name="width">
+
+		<label for="height">Transcode height (optional)</label>
+		<input type="text" id="height" name="height">
+
+		<button type="submit">Add profile</button>
+	</form>
+
+	<p style="margin-top:2rem;font-size:0.9rem;color:#555;">
+		Changes are saved to <code>/etc/camplayer-vlc.conf</code> and VLC is restarted automatically.
+	</p>
+
+	<script>
+		(function() {
+			var proto = location.protocol === "https:" ? "wss:" : "ws:";
+			var logEl = document.getElementById("log");
+			var logSock = new WebSocket(proto + "//" + location.host + "/ws/logs");
+			logSock.onmessage = function(ev) {
+				logEl.textContent += ev.data + "\n";
+				logEl.scrollTop = logEl.scrollHeight;
+			};
+
+			var indicator = document.getElementById("state-indicator");
+			var label = document.getElementById("state-label");
+			var eventSock = new WebSocket(proto + "//" + location.host + "/ws/events");
+			eventSock.onmessage = function(ev) {
+				var data = JSON.parse(ev.data);
+				indicator.className = data.type;
+				label.textContent = data.type;
+			};
+		})();
+	</script>
+</body>
+</html>
+`))
+
+type pageData struct {
+	Profiles       []CameraProfile
+	Selected       string
+	Status         SupervisorStatus
+	HLSPlaylistURL string
+	CSRFToken      string
+	Message        string
+	Error          string
+}
+
+func startWebServer(ctx context.Context) error {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		renderPage(w, "", "")
+	})
+
+	mux.HandleFunc("/supervisor/start", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		if err := r.ParseForm(); err != nil || !checkCSRF(r) {
+			http.Error(w, "Invalid request", http.StatusForbidden)
+			return
+		}
+		requestStart()
+		renderPage(w, "Start requested.", "")
+	})
+
+	mux.HandleFunc("/api/status", handleAPIStatus)
+	mux.HandleFunc("/api/config", handleAPIConfig)
+	mux.HandleFunc("/api/restart", handleAPIControl(requestRestart, "Restart requested"))
+	mux.HandleFunc("/api/stop", handleAPIControl(requestStop, "Stop requested"))
+	mux.HandleFunc("/api/start", handleAPIControl(requestStart, "Start requested"))
+	mux.HandleFunc("/api/logs/crashes", handleAPICrashes)
+	mux.HandleFunc("/ws/logs", handleWSLogs)
+	mux.HandleFunc("/ws/events", handleWSEvents)
+	mux.Handle("/hls/", http.StripPrefix("/hls/", http.FileServer(http.Dir(hlsBaseDir))))
+	mux.HandleFunc("/assets/hls.min.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write(hlsJS)
+	})
+
+	mux.HandleFunc("/profile/add", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad form", http.StatusBadRequest)
+			return
+		}
+		if !checkCSRF(r) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		name := strings.TrimSpace(r.Form.Get("name"))
+		rtsp := strings.TrimSpace(r.Form.Get("rtsp_url"))
+		transport := strings.TrimSpace(r.Form.Get("transport"))
+		restream := r.Form.Get("restream") != ""
+		transcode := r.Form.Get("transcode") != ""
+		bitrate, _ := strconv.Atoi(strings.TrimSpace(r.Form.Get("bitrate")))
+		width, _ := strconv.Atoi(strings.TrimSpace(r.Form.Get("width")))
+		height, _ := strconv.Atoi(strings.TrimSpace(r.Form.Get("height")))
+
+		if name == "" || rtsp == "" {
+			renderPage(w, "", "Name and RTSP URL are required")
+			return
+		}
+		if !validProfileName(name) {
+			renderPage(w, "", "Profile name may only contain letters, numbers, underscores and hyphens")
+			return
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &Config{}
+		}
+		if cfg.findProfile(name) != -1 {
+			renderPage(w, "", "A profile named \""+name+"\" already exists")
+			return
+		}
+
+		cfg.Profiles = append(cfg.Profiles, CameraProfile{
+			Name:                 name,
+			RTSPURL:              rtsp,
+			Transport:            transport,
+			Restream:             restream,
+			Transcode:            transcode,
+			TranscodeBitrateKbps: bitrate,
+			TranscodeWidth:       width,
+			TranscodeHeight:      height,
+		})
+		if cfg.Selected == "" {
+			cfg.Selected = name
+		}
+
+		if err := saveConfig(cfg); err != nil {
+			renderPage(w, "", "Failed to save config: "+err.Error())
+			return
+		}
+		renderPage(w, "Profile added.", "")
+	})
+
+	mux.HandleFunc("/profile/delete", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad form", http.StatusBadRequest)
+			return
+		}
+		if !checkCSRF(r) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		name := strings.TrimSpace(r.Form.Get("name"))
+
+		cfg, err := loadConfig()
+		if err != nil {
+			renderPage(w, "", "Failed to load config: "+err.Error())
+			return
+		}
+		idx := cfg.findProfile(name)
+		if idx == -1 {
+			renderPage(w, "", "No such profile: "+name)
+			return
+		}
+		cfg.Profiles = append(cfg.Profiles[:idx], cfg.Profiles[idx+1:]...)
+		if cfg.Selected == name {
+			cfg.Selected = ""
+		}
+
+		if err := saveConfig(cfg); err != nil {
+			renderPage(w, "", "Failed to save config: "+err.Error())
+			return
+		}
+		renderPage(w, "Profile deleted.", "")
+	})
+
+	mux.HandleFunc("/profile/select", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad form", http.StatusBadRequest)
+			return
+		}
+		if !checkCSRF(r) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		name := strings.TrimSpace(r.Form.Get("name"))
+
+		cfg, err := loadConfig()
+		if err != nil {
+			renderPage(w, "", "Failed to load config: "+err.Error())
+			return
+		}
+		if cfg.findProfile(name) == -1 {
+			renderPage(w, "", "No such profile: "+name)
+			return
+		}
+		cfg.Selected = name
+
+		if err := saveConfig(cfg); err != nil {
+			renderPage(w, "", "Failed to save config: "+err.Error())
+			return
+		}
+
+		requestStart()
+		renderPage(w, "Switched active profile to \""+name+"\". VLC is restarting...", "")
+	})
+
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: requireAuth(mux),
+	}
+
+	// Shutdown when context is cancelled
+	goSafe("webServerShutdown", func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	})
+
+	cfg, _ := loadConfig()
+	if cfg != nil && cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		appLog.Info("web UI listening", "addr", srv.Addr, "tls", true)
+		if err := srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	}
+
+	appLog.Info("web UI listening", "addr", srv.Addr, "tls", false)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func renderPage(w http.ResponseWriter, msg, errMsg string) {
+	data := pageData{Message: msg, Error: errMsg}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		if data.Error == "" {
+			data.Error = "Failed to load config: " + err.Error()
+		}
+	} else {
+		data.Profiles = cfg.Profiles
+		data.Selected = cfg.Selected
+		if active, ok := cfg.ActiveProfile(); ok && active.Restream {
+			data.HLSPlaylistURL = hlsPlaylistURL(active.Name)
+		}
+	}
+	data.Status = sup.Snapshot()
+	data.CSRFToken = csrfToken
+
+	if err := pageTmpl.Execute(w, data); err != nil {
+		appLog.Error("template execute error", "err", err)
+	}
+}
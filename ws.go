@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// upgrader is shared by /ws/logs and /ws/events. This module only ever
+// serves its own config page, so there's no cross-origin request to worry
+// about; CheckOrigin is left permissive for LAN access from any device.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// watchForClose reads (and discards) incoming frames so we notice when the
+// browser closes the socket, then closes done.
+func watchForClose(conn *websocket.Conn, done chan<- struct{}) {
+	defer close(done)
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+func handleWSLogs(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		appLog.Error("ws logs: upgrade failed", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	lines, history, cancel := vlcLog.Subscribe()
+	defer cancel()
+
+	closed := make(chan struct{})
+	goSafe("wsWatchClose", func() { watchForClose(conn, closed) })
+
+	for _, line := range history {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(line)); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-closed:
+			return
+		case line := <-lines:
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(line)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func handleWSEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		appLog.Error("ws events: upgrade failed", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	events, cancel := supEvents.Subscribe()
+	defer cancel()
+
+	closed := make(chan struct{})
+	goSafe("wsWatchClose", func() { watchForClose(conn, closed) })
+
+	// Send the current status immediately so a fresh viewer isn't blank
+	// until the next transition.
+	initial := sup.Snapshot()
+	_ = conn.WriteJSON(SupervisorEvent{Time: initial.StartedAt, Type: initial.State, Profile: initial.Profile, Detail: initial.LastError})
+
+	for {
+		select {
+		case <-closed:
+			return
+		case ev := <-events:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}
+}